@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupeTTL is how long we remember an event_id after first accepting it.
+// Slack stops retrying well before this window closes, so it's enough to
+// collapse every retried delivery onto a single processing attempt.
+const dedupeTTL = 10 * time.Minute
+
+// dedupeCleanupInterval controls how often expired entries are swept out.
+const dedupeCleanupInterval = time.Minute
+
+// dedupeCache is a simple in-memory, TTL-based dedupe cache keyed by Slack's
+// event_id. A full LRU isn't needed here: Slack only retries for a few
+// minutes, so a periodic sweep of expired entries keeps memory bounded.
+type dedupeCache struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	ttl     time.Duration
+	closeCh chan struct{}
+}
+
+func newDedupeCache(ttl time.Duration) *dedupeCache {
+	c := &dedupeCache{
+		seen:    make(map[string]time.Time),
+		ttl:     ttl,
+		closeCh: make(chan struct{}),
+	}
+	go c.cleanupLoop()
+	return c
+}
+
+// Seen reports whether key has already been recorded within the TTL window,
+// and records it if not. Callers use the return value to decide whether this
+// delivery is a duplicate they should drop.
+func (c *dedupeCache) Seen(key string) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if expiry, ok := c.seen[key]; ok && now.Before(expiry) {
+		return true
+	}
+	c.seen[key] = now.Add(c.ttl)
+	return false
+}
+
+func (c *dedupeCache) cleanupLoop() {
+	ticker := time.NewTicker(dedupeCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			c.mu.Lock()
+			for key, expiry := range c.seen {
+				if now.After(expiry) {
+					delete(c.seen, key)
+				}
+			}
+			c.mu.Unlock()
+		case <-c.closeCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+)
+
+// newRequestID generates a short identifier for a single inbound delivery
+// (an HTTP request or a Socket Mode envelope) so its accepted/deduped/failed
+// log lines can be correlated back to that specific delivery, even across
+// Slack retries of the same event_id.
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// dispatchEvent applies dedupe and worker-pool backpressure before handing
+// an event_callback payload to handler. It's shared by every transport so a
+// retried HTTP delivery and a redelivered Socket Mode envelope for the same
+// event_id are only ever processed once. requestID identifies this specific
+// delivery attempt, distinct from Slack's own event_id.
+func dispatchEvent(requestID string, payload SlackEventPayload, dedupe *dedupeCache, pool *workerPool, metrics *eventMetrics, handler func(SlackEventPayload) error) {
+	if payload.EventID != "" && dedupe.Seen(payload.EventID) {
+		metrics.deduped.Add(1)
+		log.Printf("Dropping duplicate event request_id=%s event_id=%s team_id=%s", requestID, payload.EventID, payload.TeamID)
+		return
+	}
+
+	submitted := pool.Submit(func() {
+		log.Printf("Processing event request_id=%s event_id=%s team_id=%s", requestID, payload.EventID, payload.TeamID)
+		if err := handler(payload); err != nil {
+			metrics.failed.Add(1)
+			log.Printf("Event request_id=%s event_id=%s team_id=%s failed: %v", requestID, payload.EventID, payload.TeamID, err)
+		}
+	})
+	if !submitted {
+		metrics.failed.Add(1)
+		log.Printf("Dropping event request_id=%s event_id=%s team_id=%s: worker pool queue is full", requestID, payload.EventID, payload.TeamID)
+		return
+	}
+	metrics.accepted.Add(1)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// InteractionPayload covers the shapes Slack sends to the interactivity
+// endpoint: block_actions, view_submission, and view_closed.
+type InteractionPayload struct {
+	Type      string `json:"type"`
+	TriggerID string `json:"trigger_id"`
+	Team      struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	ResponseURL string          `json:"response_url"`
+	Actions     []BlockAction   `json:"actions,omitempty"`
+	View        *ModalView      `json:"view,omitempty"`
+	State       json.RawMessage `json:"state,omitempty"`
+}
+
+// BlockAction is a single interacted-with element from a block_actions
+// payload, e.g. a button click.
+type BlockAction struct {
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	Value    string `json:"value"`
+}
+
+// ModalView is the subset of a Slack modal view we round-trip through
+// view_submission/view_closed payloads and views.open/views.update calls.
+type ModalView struct {
+	ID              string          `json:"id,omitempty"`
+	CallbackID      string          `json:"callback_id"`
+	PrivateMetadata string          `json:"private_metadata,omitempty"`
+	State           json.RawMessage `json:"state,omitempty"`
+}
+
+// InteractionHandler processes a single interactivity payload routed to it
+// by an interactionDispatcher.
+type InteractionHandler func(InteractionPayload) error
+
+// interactionDispatcher routes interactivity payloads to handlers
+// registered by callback_id (for view_submission/view_closed) or action_id
+// (for block_actions), so Acorn tasks can drive multi-step flows without
+// the HTTP layer knowing about their specifics.
+type interactionDispatcher struct {
+	mu           sync.RWMutex
+	byCallbackID map[string]InteractionHandler
+	byActionID   map[string]InteractionHandler
+}
+
+func newInteractionDispatcher() *interactionDispatcher {
+	return &interactionDispatcher{
+		byCallbackID: make(map[string]InteractionHandler),
+		byActionID:   make(map[string]InteractionHandler),
+	}
+}
+
+// HandleCallbackID registers a handler for view_submission/view_closed
+// payloads whose view has the given callback_id.
+func (d *interactionDispatcher) HandleCallbackID(callbackID string, handler InteractionHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byCallbackID[callbackID] = handler
+}
+
+// HandleActionID registers a handler for block_actions payloads containing
+// an action with the given action_id.
+func (d *interactionDispatcher) HandleActionID(actionID string, handler InteractionHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byActionID[actionID] = handler
+}
+
+func (d *interactionDispatcher) dispatch(payload InteractionPayload) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if payload.View != nil {
+		if handler, ok := d.byCallbackID[payload.View.CallbackID]; ok {
+			return handler(payload)
+		}
+	}
+	for _, action := range payload.Actions {
+		if handler, ok := d.byActionID[action.ActionID]; ok {
+			return handler(payload)
+		}
+	}
+
+	log.Printf("No interaction handler registered for type=%s", payload.Type)
+	return nil
+}
+
+// handleSlackInteractivity parses the URL-encoded `payload` field Slack
+// posts for block actions, view submissions, and view closures, then routes
+// it through dispatcher.
+func handleSlackInteractivity(dispatcher *interactionDispatcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+			return
+		}
+
+		var payload InteractionPayload
+		if err := json.Unmarshal([]byte(r.FormValue("payload")), &payload); err != nil {
+			http.Error(w, "Failed to parse interactivity payload", http.StatusBadRequest)
+			return
+		}
+
+		// Ack immediately; Slack expects a response within 3 seconds and
+		// the registered handler may call back out to Acorn or Slack itself.
+		w.WriteHeader(http.StatusOK)
+
+		go func() {
+			if err := dispatcher.dispatch(payload); err != nil {
+				log.Printf("Interaction dispatch failed (type=%s): %v", payload.Type, err)
+			}
+		}()
+	}
+}
+
+// handleSlackCommands decodes slash command form posts into
+// SlackCommandPayload and forwards them to Acorn with a distinct command
+// context, replying via the command's response_url once Acorn returns.
+func handleSlackCommands(accessToken string, taskAPI string, tokenStore TokenStore, defaultBotToken string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+			return
+		}
+
+		cmd := SlackCommandPayload{
+			Text:        r.FormValue("text"),
+			ChannelID:   r.FormValue("channel_id"),
+			ThreadTS:    r.FormValue("thread_ts"),
+			ResponseURL: r.FormValue("response_url"),
+			UserID:      r.FormValue("user_id"),
+		}
+		command := r.FormValue("command")
+		teamID := r.FormValue("team_id")
+
+		log.Printf("Received slash command %s from team %s", command, teamID)
+
+		// Ack immediately; Acorn may take longer than Slack's synchronous
+		// response window, so the real reply goes back via response_url.
+		w.WriteHeader(http.StatusOK)
+
+		go func() {
+			if err := forwardCommandToAcorn(cmd, command, teamID, accessToken, taskAPI, tokenStore, defaultBotToken); err != nil {
+				log.Printf("Failed to forward command %s to Acorn: %v", command, err)
+			}
+		}()
+	}
+}
+
+// forwardCommandToAcorn calls Acorn with the slash command's text as the
+// query and posts the result back via the command's response_url, falling
+// back to a direct chat.postMessage/chat.update pair on the resolved
+// workspace's bot token if we have one, same as forwardEventToAcorn.
+func forwardCommandToAcorn(cmd SlackCommandPayload, command string, teamID string, accessToken string, taskAPI string, tokenStore TokenStore, defaultBotToken string) error {
+	botToken := defaultBotToken
+	if team, err := tokenStore.Get(context.Background(), teamID); err != nil {
+		log.Printf("No stored installation for team %s: %v", teamID, err)
+	} else {
+		log.Printf("Handling command %s for team %s (bot user %s)", command, team.TeamID, team.BotUserID)
+		botToken = team.BotToken
+	}
+
+	var slack *slackClient
+	var placeholderTS string
+	if botToken != "" {
+		slack = newSlackClient(botToken)
+		posted, err := slack.PostMessage(cmd.ChannelID, "", thinkingPlaceholderText)
+		if err != nil {
+			log.Printf("Failed to post thinking placeholder for command %s: %v", command, err)
+		} else {
+			placeholderTS = posted.TS
+		}
+	}
+
+	apiBody := APIRequestBody{
+		CHANNEL_ID: cmd.ChannelID,
+		USER_ID:    cmd.UserID,
+		QUERY:      fmt.Sprintf("%s %s", command, cmd.Text),
+	}
+
+	respBody, err := callAcornAPI(accessToken, taskAPI, apiBody)
+	if err != nil {
+		s := fmt.Sprintf("Sorry, I hit an error: %v", err)
+		if placeholderTS != "" {
+			updatePlaceholder(slack, cmd.ChannelID, placeholderTS, s)
+			return err
+		}
+		if postErr := postToResponseURL(cmd.ResponseURL, s); postErr != nil {
+			log.Printf("Failed to post command error to response_url: %v", postErr)
+		}
+		return err
+	}
+
+	if placeholderTS != "" {
+		updatePlaceholder(slack, cmd.ChannelID, placeholderTS, respBody)
+		return nil
+	}
+	return postToResponseURL(cmd.ResponseURL, respBody)
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 )
 
 // SlackCommandPayload represents the incoming Slack slash command payload
@@ -31,6 +34,8 @@ type APIRequestBody struct {
 type SlackEventPayload struct {
 	Type      string            `json:"type"`
 	Challenge string            `json:"challenge"`
+	TeamID    string            `json:"team_id"`
+	EventID   string            `json:"event_id"`
 	Event     SlackMessageEvent `json:"event"`
 }
 
@@ -45,6 +50,38 @@ type SlackMessageEvent struct {
 	BotID       string `json:"bot_id"`
 }
 
+// defaultOAuthScopes are requested during installation when
+// SLACK_OAUTH_SCOPES isn't set.
+const defaultOAuthScopes = "app_mentions:read,chat:write,im:history,im:read,im:write"
+
+// Defaults for the event worker pool, overridable via env vars so
+// deployments can tune concurrency to their Acorn backend's capacity.
+const (
+	defaultWorkerConcurrency = 8
+	defaultWorkerQueueSize   = 256
+)
+
+func workerConcurrency() int {
+	return envIntOrDefault("SLACK_WORKER_CONCURRENCY", defaultWorkerConcurrency)
+}
+
+func workerQueueSize() int {
+	return envIntOrDefault("SLACK_WORKER_QUEUE_SIZE", defaultWorkerQueueSize)
+}
+
+func envIntOrDefault(name string, fallback int) int {
+	val := os.Getenv(name)
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid value %q for %s, using default %d", val, name, fallback)
+		return fallback
+	}
+	return n
+}
+
 func main() {
 	// Check for required environment variable
 	accessToken := os.Getenv("OBOT_ACCESS_TOKEN")
@@ -54,105 +91,196 @@ func main() {
 
 	taskAPI := os.Getenv("TASK_API_URL")
 
-	http.HandleFunc("/slack/events", handleSlackEvents(accessToken, taskAPI))
+	signingSecret := os.Getenv("SLACK_SIGNING_SECRET")
+	if signingSecret == "" {
+		log.Fatal("SLACK_SIGNING_SECRET environment variable must be set")
+	}
+
+	tokenStore, err := newTokenStore()
+	if err != nil {
+		log.Fatalf("Failed to initialize token store: %v", err)
+	}
+
+	// defaultBotToken is used when a workspace hasn't gone through the OAuth
+	// install flow, e.g. a single-workspace deployment with a bot token
+	// configured directly.
+	defaultBotToken := os.Getenv("SLACK_BOT_TOKEN")
+
+	oauthCfg := oauthConfig{
+		clientID:     os.Getenv("SLACK_CLIENT_ID"),
+		clientSecret: os.Getenv("SLACK_CLIENT_SECRET"),
+		scopes:       os.Getenv("SLACK_OAUTH_SCOPES"),
+		redirectURL:  os.Getenv("SLACK_OAUTH_REDIRECT_URL"),
+	}
+	if oauthCfg.scopes == "" {
+		oauthCfg.scopes = defaultOAuthScopes
+	}
+	oauthStates := newOAuthStateStore()
+
+	if oauthCfg.clientID != "" {
+		http.HandleFunc("/slack/oauth/install", handleSlackOAuthInstall(oauthCfg, oauthStates))
+		http.HandleFunc("/slack/oauth/callback", handleSlackOAuthCallback(oauthCfg, oauthStates, tokenStore))
+	}
+
+	interactions := newInteractionDispatcher()
+	http.HandleFunc("/slack/interactivity", verifySlackSignature(signingSecret, handleSlackInteractivity(interactions)))
+	http.HandleFunc("/slack/commands", verifySlackSignature(signingSecret, handleSlackCommands(accessToken, taskAPI, tokenStore, defaultBotToken)))
 
 	port := "8088"
 	if envPort := os.Getenv("PORT"); envPort != "" {
 		port = envPort
 	}
 
-	fmt.Printf("Server starting on port %s...\n", port)
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatal(err)
+	handler := func(payload SlackEventPayload) error {
+		return forwardEventToAcorn(payload, accessToken, taskAPI, tokenStore, defaultBotToken)
 	}
-}
 
-func handleSlackEvents(accessToken string, taskAPI string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	dedupe := newDedupeCache(dedupeTTL)
+	pool := newWorkerPool(workerConcurrency(), workerQueueSize())
+	metrics := newEventMetrics()
+	metricsDone := make(chan struct{})
+	defer close(metricsDone)
+	go metrics.logPeriodically(time.Minute, metricsDone)
 
-		rawEventData, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Failed to read request body", http.StatusInternalServerError)
-			return
+	var source EventSource
+	if appToken := os.Getenv("SLACK_APP_TOKEN"); appToken != "" {
+		source = &socketModeEventSource{
+			appToken:        appToken,
+			dedupe:          dedupe,
+			pool:            pool,
+			metrics:         metrics,
+			interactions:    interactions,
+			accessToken:     accessToken,
+			taskAPI:         taskAPI,
+			tokenStore:      tokenStore,
+			defaultBotToken: defaultBotToken,
 		}
-		log.Printf("Raw event data: %s", string(rawEventData))
 
-		// Read the request body
-		var payload SlackEventPayload
-		if err := json.Unmarshal(rawEventData, &payload); err != nil {
-			http.Error(w, "Failed to parse request body", http.StatusBadRequest)
-			return
-		}
+		// Socket Mode doesn't carry HTTP traffic, but OAuth install/callback
+		// still need a listener, so keep serving those in the background.
+		go func() {
+			fmt.Printf("HTTP server (OAuth only) starting on port %s...\n", port)
+			if err := http.ListenAndServe(":"+port, nil); err != nil {
+				log.Printf("OAuth HTTP server stopped: %v", err)
+			}
+		}()
+	} else {
+		source = &httpEventSource{addr: ":" + port, signingSecret: signingSecret, dedupe: dedupe, pool: pool, metrics: metrics}
+	}
 
-		// Handle URL verification challenge
-		if payload.Type == "url_verification" {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]string{
-				"challenge": payload.Challenge,
-			})
-			return
-		}
+	if err := source.Run(context.Background(), handler); err != nil {
+		log.Fatal(err)
+	}
+}
 
-		// Handle message events
-		if payload.Type == "event_callback" && (payload.Event.Type == "app_mention" || (payload.Event.ChannelType == "im" && payload.Event.Type == "message" && payload.Event.BotID == "")) {
-			// Prepare the request body for the Acorn API
-			apiBody := APIRequestBody{
-				THREAD_ID:  payload.Event.ThreadTS,
-				CHANNEL_ID: payload.Event.ChannelID,
-				USER_ID:    payload.Event.User,
-				QUERY:      payload.Event.Text,
-			}
+// thinkingPlaceholderText is posted immediately after a mention or DM comes
+// in, then replaced with the real reply once Acorn responds, so users get
+// feedback within Slack's expected turnaround.
+const thinkingPlaceholderText = "_Thinking..._"
 
-			// If message is not in a thread, use the message TS as thread ID
-			if apiBody.THREAD_ID == "" {
-				apiBody.THREAD_ID = payload.Event.TS
-			}
+// forwardEventToAcorn builds the Acorn Task API request for a Slack message
+// event, sends it, and posts the result back into the originating Slack
+// thread. It's shared by every transport (HTTP webhook, Socket Mode) so they
+// stay in sync as Acorn's contract evolves.
+func forwardEventToAcorn(payload SlackEventPayload, accessToken string, taskAPI string, tokenStore TokenStore, defaultBotToken string) error {
+	if payload.Type != "event_callback" {
+		return nil
+	}
+	if !(payload.Event.Type == "app_mention" || (payload.Event.ChannelType == "im" && payload.Event.Type == "message" && payload.Event.BotID == "")) {
+		return nil
+	}
 
-			if payload.Event.ChannelType == "im" {
-				apiBody.THREAD_ID = ""
-			}
+	botToken := defaultBotToken
+	if team, err := tokenStore.Get(context.Background(), payload.TeamID); err != nil {
+		log.Printf("No stored installation for team %s: %v", payload.TeamID, err)
+	} else {
+		log.Printf("Handling event for team %s (bot user %s)", team.TeamID, team.BotUserID)
+		botToken = team.BotToken
+	}
 
-			// Convert the body to JSON
-			jsonBody, err := json.Marshal(apiBody)
-			if err != nil {
-				http.Error(w, "Failed to create request body", http.StatusInternalServerError)
-				return
-			}
+	// Prepare the request body for the Acorn API
+	apiBody := APIRequestBody{
+		THREAD_ID:  payload.Event.ThreadTS,
+		CHANNEL_ID: payload.Event.ChannelID,
+		USER_ID:    payload.Event.User,
+		QUERY:      payload.Event.Text,
+	}
 
-			// Create the request to the Acorn API
-			apiURL := taskAPI
-			req, err := http.NewRequest(http.MethodPost, apiURL, bytes.NewBuffer(jsonBody))
-			if err != nil {
-				http.Error(w, "Failed to create API request", http.StatusInternalServerError)
-				return
-			}
+	// If message is not in a thread, use the message TS as thread ID
+	if apiBody.THREAD_ID == "" {
+		apiBody.THREAD_ID = payload.Event.TS
+	}
 
-			// Set headers
-			req.Header.Set("Content-Type", "application/json")
-			req.Header.Set("Cookie", "obot_access_token="+accessToken)
+	if payload.Event.ChannelType == "im" {
+		apiBody.THREAD_ID = ""
+	}
 
-			// Make the request
-			client := &http.Client{}
-			resp, err := client.Do(req)
-			if err != nil {
-				http.Error(w, "Failed to send request to API", http.StatusInternalServerError)
-				return
-			}
-			// Read and print the response body
-			respBody, err := io.ReadAll(resp.Body)
-			if err != nil {
-				http.Error(w, "Failed to read response body", http.StatusInternalServerError)
-				return
-			}
-			log.Printf("Response from API: %s", string(respBody))
-			defer resp.Body.Close()
+	// Post a "thinking..." placeholder right away so the user sees
+	// something before the Acorn call returns. We reply in the same
+	// thread we're about to send Acorn, falling back to no reply if we
+	// don't have a bot token for this workspace yet.
+	var slack *slackClient
+	var placeholderTS string
+	if botToken != "" {
+		slack = newSlackClient(botToken)
+		posted, err := slack.PostMessage(payload.Event.ChannelID, apiBody.THREAD_ID, thinkingPlaceholderText)
+		if err != nil {
+			log.Printf("Failed to post thinking placeholder: %v", err)
+		} else {
+			placeholderTS = posted.TS
 		}
+	}
+
+	respBody, err := callAcornAPI(accessToken, taskAPI, apiBody)
+	if err != nil {
+		s := fmt.Sprintf("Sorry, I hit an error reaching the backend: %v", err)
+		updatePlaceholder(slack, payload.Event.ChannelID, placeholderTS, s)
+		return err
+	}
+
+	updatePlaceholder(slack, payload.Event.ChannelID, placeholderTS, respBody)
+	return nil
+}
+
+// callAcornAPI sends body to the Acorn Task API and returns its response,
+// shared by every caller (event replies, slash commands) that needs to
+// round-trip through Acorn.
+func callAcornAPI(accessToken string, taskAPI string, body APIRequestBody) (string, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request body: %w", err)
+	}
 
-		// Respond with 200 OK for all event callbacks
-		w.WriteHeader(http.StatusOK)
+	req, err := http.NewRequest(http.MethodPost, taskAPI, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create API request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", "obot_access_token="+accessToken)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request to API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	log.Printf("Response from API: %s", string(respBody))
+	return string(respBody), nil
+}
+
+// updatePlaceholder replaces the "thinking..." placeholder with the final
+// reply text. It's a no-op if we never managed to post a placeholder (e.g.
+// no bot token configured for this workspace).
+func updatePlaceholder(slack *slackClient, channel, placeholderTS, text string) {
+	if slack == nil || placeholderTS == "" {
+		return
+	}
+	if _, err := slack.UpdateMessage(channel, placeholderTS, text); err != nil {
+		log.Printf("Failed to update placeholder message: %v", err)
 	}
 }
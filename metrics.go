@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// eventMetrics tracks coarse counters for inbound event processing,
+// logged periodically so operators can see throughput and drop rates
+// without needing a separate metrics backend.
+type eventMetrics struct {
+	accepted atomic.Uint64
+	deduped  atomic.Uint64
+	failed   atomic.Uint64
+}
+
+func newEventMetrics() *eventMetrics {
+	return &eventMetrics{}
+}
+
+// logPeriodically logs a snapshot of the counters every interval until ctx
+// is done.
+func (m *eventMetrics) logPeriodically(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			log.Printf("Event metrics: accepted=%d deduped=%d failed=%d",
+				m.accepted.Load(), m.deduped.Load(), m.failed.Load())
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+// oauthConfig holds the Slack app credentials and settings needed to run
+// the OAuth v2 install flow.
+type oauthConfig struct {
+	clientID     string
+	clientSecret string
+	scopes       string
+	redirectURL  string
+}
+
+// oauthStateStore tracks outstanding state parameters so the callback can
+// reject requests that didn't originate from our own install link.
+type oauthStateStore struct {
+	mu     sync.Mutex
+	states map[string]time.Time
+}
+
+func newOAuthStateStore() *oauthStateStore {
+	return &oauthStateStore{states: make(map[string]time.Time)}
+}
+
+func (s *oauthStateStore) generate() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state] = time.Now().Add(oauthStateTTL)
+	return state, nil
+}
+
+func (s *oauthStateStore) consume(state string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry, ok := s.states[state]
+	delete(s.states, state)
+	if !ok {
+		return false
+	}
+	return time.Now().Before(expiry)
+}
+
+// handleSlackOAuthInstall redirects the user to Slack's OAuth v2 authorize
+// page, with a fresh CSRF state parameter.
+func handleSlackOAuthInstall(cfg oauthConfig, states *oauthStateStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, err := states.generate()
+		if err != nil {
+			http.Error(w, "Failed to start installation", http.StatusInternalServerError)
+			return
+		}
+
+		authorizeURL := url.URL{
+			Scheme: "https",
+			Host:   "slack.com",
+			Path:   "/oauth/v2/authorize",
+		}
+		q := authorizeURL.Query()
+		q.Set("client_id", cfg.clientID)
+		q.Set("scope", cfg.scopes)
+		q.Set("redirect_uri", cfg.redirectURL)
+		q.Set("state", state)
+		authorizeURL.RawQuery = q.Encode()
+
+		http.Redirect(w, r, authorizeURL.String(), http.StatusFound)
+	}
+}
+
+// oauthAccessResponse is the subset of Slack's oauth.v2.access response we
+// care about.
+type oauthAccessResponse struct {
+	OK          bool   `json:"ok"`
+	Error       string `json:"error"`
+	AccessToken string `json:"access_token"`
+	BotUserID   string `json:"bot_user_id"`
+	Team        struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	Enterprise struct {
+		ID string `json:"id"`
+	} `json:"enterprise"`
+}
+
+// handleSlackOAuthCallback exchanges the authorization code for a bot token
+// via oauth.v2.access and stores it in the TokenStore keyed by team id.
+func handleSlackOAuthCallback(cfg oauthConfig, states *oauthStateStore, store TokenStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := r.URL.Query().Get("state")
+		if state == "" || !states.consume(state) {
+			http.Error(w, "Invalid or expired state parameter", http.StatusBadRequest)
+			return
+		}
+
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "Missing code parameter", http.StatusBadRequest)
+			return
+		}
+
+		form := url.Values{}
+		form.Set("client_id", cfg.clientID)
+		form.Set("client_secret", cfg.clientSecret)
+		form.Set("code", code)
+		form.Set("redirect_uri", cfg.redirectURL)
+
+		resp, err := http.PostForm("https://slack.com/api/oauth.v2.access", form)
+		if err != nil {
+			http.Error(w, "Failed to reach Slack", http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			http.Error(w, "Failed to read Slack response", http.StatusInternalServerError)
+			return
+		}
+
+		var accessResp oauthAccessResponse
+		if err := json.Unmarshal(body, &accessResp); err != nil {
+			http.Error(w, "Failed to parse Slack response", http.StatusInternalServerError)
+			return
+		}
+		if !accessResp.OK {
+			log.Printf("oauth.v2.access failed: %s", accessResp.Error)
+			http.Error(w, "Slack installation failed", http.StatusBadGateway)
+			return
+		}
+
+		team := &Team{
+			TeamID:       accessResp.Team.ID,
+			EnterpriseID: accessResp.Enterprise.ID,
+			BotToken:     accessResp.AccessToken,
+			BotUserID:    accessResp.BotUserID,
+		}
+		if err := store.Save(context.Background(), team); err != nil {
+			log.Printf("Failed to persist installation for team %s: %v", team.TeamID, err)
+			http.Error(w, "Failed to save installation", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body><h1>Installation complete</h1><p>You can close this window.</p></body></html>")
+	}
+}
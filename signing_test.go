@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSigningSecret = "test-signing-secret"
+
+func sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("v0:%s:%s", timestamp, body)))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newSignedRequest(t *testing.T, secret, body string, ts time.Time, signature string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(ts.Unix(), 10))
+	req.Header.Set("X-Slack-Signature", signature)
+	return req
+}
+
+func TestVerifySlackSignatureAcceptsValidRequest(t *testing.T) {
+	body := `{"type":"event_callback"}`
+	ts := time.Now()
+	req := newSignedRequest(t, testSigningSecret, body, ts, sign(testSigningSecret, strconv.FormatInt(ts.Unix(), 10), body))
+
+	called := false
+	handler := verifySlackSignature(testSigningSecret, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if !called {
+		t.Fatal("expected wrapped handler to be called for a validly signed request")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+}
+
+func TestVerifySlackSignatureRejectsWrongSignature(t *testing.T) {
+	body := `{"type":"event_callback"}`
+	ts := time.Now()
+	req := newSignedRequest(t, testSigningSecret, body, ts, sign("wrong-secret", strconv.FormatInt(ts.Unix(), 10), body))
+
+	called := false
+	handler := verifySlackSignature(testSigningSecret, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("expected wrapped handler not to be called for an invalid signature")
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", rec.Code)
+	}
+}
+
+func TestVerifySlackSignatureRejectsStaleTimestamp(t *testing.T) {
+	body := `{"type":"event_callback"}`
+	ts := time.Now().Add(-10 * time.Minute)
+	req := newSignedRequest(t, testSigningSecret, body, ts, sign(testSigningSecret, strconv.FormatInt(ts.Unix(), 10), body))
+
+	called := false
+	handler := verifySlackSignature(testSigningSecret, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("expected wrapped handler not to be called for a stale timestamp")
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", rec.Code)
+	}
+}
+
+func TestVerifySlackSignatureRejectsMissingHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", strings.NewReader(`{}`))
+
+	called := false
+	handler := verifySlackSignature(testSigningSecret, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if called {
+		t.Fatal("expected wrapped handler not to be called when signature headers are missing")
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", rec.Code)
+	}
+}
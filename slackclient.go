@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// slackAPIBaseURL is the root of Slack's Web API.
+const slackAPIBaseURL = "https://slack.com/api"
+
+// slackClient posts and updates chat messages on behalf of a single
+// workspace's bot token.
+type slackClient struct {
+	botToken string
+}
+
+func newSlackClient(botToken string) *slackClient {
+	return &slackClient{botToken: botToken}
+}
+
+// slackMessageResponse is the subset of chat.postMessage/chat.update
+// responses we care about.
+type slackMessageResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error"`
+	TS      string `json:"ts"`
+	Channel string `json:"channel"`
+}
+
+// PostMessage posts text into channel, optionally as a thread reply, and
+// returns the message timestamp so it can later be updated.
+func (c *slackClient) PostMessage(channel, threadTS, text string) (*slackMessageResponse, error) {
+	body := map[string]string{
+		"channel": channel,
+		"text":    text,
+	}
+	if threadTS != "" {
+		body["thread_ts"] = threadTS
+	}
+	return c.call("chat.postMessage", body)
+}
+
+// UpdateMessage replaces the text of a previously posted message, used to
+// turn a "thinking..." placeholder into the final reply.
+func (c *slackClient) UpdateMessage(channel, ts, text string) (*slackMessageResponse, error) {
+	return c.call("chat.update", map[string]string{
+		"channel": channel,
+		"ts":      ts,
+		"text":    text,
+	})
+}
+
+// OpenView opens a modal via views.open, using the trigger_id from a prior
+// interaction (a slash command or block action).
+func (c *slackClient) OpenView(triggerID string, view any) error {
+	_, err := c.doCall("views.open", map[string]any{
+		"trigger_id": triggerID,
+		"view":       view,
+	})
+	return err
+}
+
+// UpdateView replaces the contents of an already-open modal via
+// views.update, so a multi-step flow can advance the same view.
+func (c *slackClient) UpdateView(viewID string, view any) error {
+	_, err := c.doCall("views.update", map[string]any{
+		"view_id": viewID,
+		"view":    view,
+	})
+	return err
+}
+
+func (c *slackClient) call(method string, body any) (*slackMessageResponse, error) {
+	respBody, err := c.doCall(method, body)
+	if err != nil {
+		return nil, err
+	}
+	var out slackMessageResponse
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", method, err)
+	}
+	return &out, nil
+}
+
+// slackAPIEnvelope is the common "ok"/"error" envelope every Slack Web API
+// response shares, regardless of method-specific fields.
+type slackAPIEnvelope struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// doCall makes a Slack Web API request and returns the raw JSON response
+// body once it's confirmed ok, for the caller to unmarshal further.
+func (c *slackClient) doCall(method string, body any) ([]byte, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, slackAPIBaseURL+"/"+method, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.botToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+
+	var envelope slackAPIEnvelope
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", method, err)
+	}
+	if !envelope.OK {
+		return nil, fmt.Errorf("%s failed: %s", method, envelope.Error)
+	}
+	return respBody, nil
+}
+
+// postToResponseURL sends a deferred reply to a slash command's
+// response_url, used when the Acorn call takes longer than Slack's
+// synchronous response window.
+func postToResponseURL(responseURL, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"response_type": "in_channel",
+		"text":          text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode response_url payload: %w", err)
+	}
+
+	resp, err := http.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post to response_url: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("response_url returned status %d", resp.StatusCode)
+	}
+	return nil
+}
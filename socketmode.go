@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// socketModeAckTimeout is the time we have to ACK an envelope before Slack
+// considers the delivery failed and retries it.
+const socketModeAckTimeout = 3 * time.Second
+
+// socketModeReconnectDelay is how long we wait before reopening a connection
+// after a disconnect or read error.
+const socketModeReconnectDelay = 2 * time.Second
+
+var errSocketModeDisconnect = errors.New("socket mode connection closed by Slack")
+
+// socketModeEventSource runs the bot over Slack's Socket Mode, so it can
+// receive events without exposing a public HTTP endpoint. Events share the
+// same dedupe cache, worker pool, and metrics as the HTTP transport.
+// Interactivity and slash command envelopes arrive on the same socket, so
+// this is also where those get routed instead of the HTTP-only handlers.
+type socketModeEventSource struct {
+	appToken        string
+	dedupe          *dedupeCache
+	pool            *workerPool
+	metrics         *eventMetrics
+	interactions    *interactionDispatcher
+	accessToken     string
+	taskAPI         string
+	tokenStore      TokenStore
+	defaultBotToken string
+}
+
+// socketModeCommandPayload is the JSON shape Slack sends for a
+// slash_commands envelope's payload, the Socket Mode equivalent of the
+// form-encoded fields posted to the HTTP /slack/commands endpoint.
+type socketModeCommandPayload struct {
+	Command     string `json:"command"`
+	Text        string `json:"text"`
+	ChannelID   string `json:"channel_id"`
+	TeamID      string `json:"team_id"`
+	UserID      string `json:"user_id"`
+	ResponseURL string `json:"response_url"`
+}
+
+// socketModeEnvelope is the outer message Slack sends over the Socket Mode
+// websocket. Payload's shape depends on Type.
+type socketModeEnvelope struct {
+	Type       string          `json:"type"`
+	EnvelopeID string          `json:"envelope_id"`
+	Reason     string          `json:"reason"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type connectionsOpenResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	URL   string `json:"url"`
+}
+
+func (s *socketModeEventSource) Run(ctx context.Context, handler func(SlackEventPayload) error) error {
+	for {
+		wsURL, err := openSocketModeConnection(s.appToken)
+		if err != nil {
+			log.Printf("apps.connections.open failed, reconnecting: %v", err)
+		} else {
+			err = s.runConnection(ctx, wsURL, handler)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				log.Printf("Socket Mode connection error, reconnecting: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(socketModeReconnectDelay):
+		}
+	}
+}
+
+func (s *socketModeEventSource) runConnection(ctx context.Context, wsURL string, handler func(SlackEventPayload) error) error {
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to dial Socket Mode websocket: %w", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return err
+		}
+
+		var envelope socketModeEnvelope
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			log.Printf("Failed to parse Socket Mode envelope: %v", err)
+			continue
+		}
+
+		switch envelope.Type {
+		case "hello":
+			log.Printf("Socket Mode connection established")
+		case "disconnect":
+			log.Printf("Socket Mode disconnect requested: %s", envelope.Reason)
+			return errSocketModeDisconnect
+		case "events_api":
+			if err := ackSocketModeEnvelope(ctx, conn, envelope.EnvelopeID); err != nil {
+				log.Printf("Failed to ack Socket Mode envelope %s: %v", envelope.EnvelopeID, err)
+				continue
+			}
+
+			var payload SlackEventPayload
+			if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+				log.Printf("Failed to parse events_api payload: %v", err)
+				continue
+			}
+			payload.Type = "event_callback"
+			dispatchEvent(newRequestID(), payload, s.dedupe, s.pool, s.metrics, handler)
+		case "interactive":
+			if err := ackSocketModeEnvelope(ctx, conn, envelope.EnvelopeID); err != nil {
+				log.Printf("Failed to ack Socket Mode envelope %s: %v", envelope.EnvelopeID, err)
+				continue
+			}
+
+			var interaction InteractionPayload
+			if err := json.Unmarshal(envelope.Payload, &interaction); err != nil {
+				log.Printf("Failed to parse interactive payload: %v", err)
+				continue
+			}
+			go func() {
+				if err := s.interactions.dispatch(interaction); err != nil {
+					log.Printf("Interaction dispatch failed (type=%s): %v", interaction.Type, err)
+				}
+			}()
+		case "slash_commands":
+			if err := ackSocketModeEnvelope(ctx, conn, envelope.EnvelopeID); err != nil {
+				log.Printf("Failed to ack Socket Mode envelope %s: %v", envelope.EnvelopeID, err)
+				continue
+			}
+
+			var command socketModeCommandPayload
+			if err := json.Unmarshal(envelope.Payload, &command); err != nil {
+				log.Printf("Failed to parse slash_commands payload: %v", err)
+				continue
+			}
+			cmd := SlackCommandPayload{
+				Text:        command.Text,
+				ChannelID:   command.ChannelID,
+				ResponseURL: command.ResponseURL,
+				UserID:      command.UserID,
+			}
+			go func() {
+				if err := forwardCommandToAcorn(cmd, command.Command, command.TeamID, s.accessToken, s.taskAPI, s.tokenStore, s.defaultBotToken); err != nil {
+					log.Printf("Failed to forward command %s to Acorn: %v", command.Command, err)
+				}
+			}()
+		default:
+			if err := ackSocketModeEnvelope(ctx, conn, envelope.EnvelopeID); err != nil {
+				log.Printf("Failed to ack Socket Mode envelope %s: %v", envelope.EnvelopeID, err)
+			}
+		}
+	}
+}
+
+func ackSocketModeEnvelope(ctx context.Context, conn *websocket.Conn, envelopeID string) error {
+	ackCtx, cancel := context.WithTimeout(ctx, socketModeAckTimeout)
+	defer cancel()
+
+	ack, err := json.Marshal(map[string]string{"envelope_id": envelopeID})
+	if err != nil {
+		return err
+	}
+	return conn.Write(ackCtx, websocket.MessageText, ack)
+}
+
+// openSocketModeConnection calls apps.connections.open to obtain a
+// short-lived websocket URL to dial.
+func openSocketModeConnection(appToken string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, "https://slack.com/api/apps.connections.open", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+appToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var out connectionsOpenResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", err
+	}
+	if !out.OK {
+		return "", fmt.Errorf("slack error: %s", out.Error)
+	}
+	return out.URL, nil
+}
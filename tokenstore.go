@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// newTokenStore builds the TokenStore configured via environment variables:
+// a file-backed store when TOKEN_STORE_PATH is set, otherwise an in-memory
+// store suitable for single-workspace or development use.
+func newTokenStore() (TokenStore, error) {
+	if path := os.Getenv("TOKEN_STORE_PATH"); path != "" {
+		return newFileTokenStore(path)
+	}
+	return newMemoryTokenStore(), nil
+}
+
+// Team holds the per-workspace installation data returned by Slack's
+// oauth.v2.access endpoint.
+type Team struct {
+	TeamID       string `json:"team_id"`
+	EnterpriseID string `json:"enterprise_id,omitempty"`
+	BotToken     string `json:"bot_token"`
+	BotUserID    string `json:"bot_user_id"`
+}
+
+// TokenStore persists per-workspace bot tokens so a single binary can
+// service installations across many Slack teams.
+type TokenStore interface {
+	Save(ctx context.Context, team *Team) error
+	Get(ctx context.Context, teamID string) (*Team, error)
+}
+
+// memoryTokenStore is a TokenStore backed by an in-memory map. Installations
+// are lost on restart; useful for local development and tests.
+type memoryTokenStore struct {
+	mu    sync.RWMutex
+	teams map[string]*Team
+}
+
+func newMemoryTokenStore() *memoryTokenStore {
+	return &memoryTokenStore{teams: make(map[string]*Team)}
+}
+
+func (s *memoryTokenStore) Save(ctx context.Context, team *Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.teams[team.TeamID] = team
+	return nil
+}
+
+func (s *memoryTokenStore) Get(ctx context.Context, teamID string) (*Team, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	team, ok := s.teams[teamID]
+	if !ok {
+		return nil, fmt.Errorf("no installation found for team %q", teamID)
+	}
+	return team, nil
+}
+
+// fileTokenStore is a TokenStore backed by a single JSON file on disk,
+// keyed by team id. It's a simple durable option for single-instance
+// deployments that don't want to run a separate database.
+type fileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func newFileTokenStore(path string) (*fileTokenStore, error) {
+	s := &fileTokenStore{path: path}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := s.writeAll(map[string]*Team{}); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *fileTokenStore) readAll() (map[string]*Team, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	teams := map[string]*Team{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &teams); err != nil {
+			return nil, err
+		}
+	}
+	return teams, nil
+}
+
+func (s *fileTokenStore) writeAll(teams map[string]*Team) error {
+	data, err := json.MarshalIndent(teams, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
+
+func (s *fileTokenStore) Save(ctx context.Context, team *Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	teams, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	teams[team.TeamID] = team
+	return s.writeAll(teams)
+}
+
+func (s *fileTokenStore) Get(ctx context.Context, teamID string) (*Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	teams, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	team, ok := teams[teamID]
+	if !ok {
+		return nil, fmt.Errorf("no installation found for team %q", teamID)
+	}
+	return team, nil
+}
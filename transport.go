@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+// EventSource delivers Slack Events API payloads to a handler, abstracting
+// over how those events actually arrive at the process — an HTTP webhook
+// or a Socket Mode websocket connection.
+type EventSource interface {
+	// Run blocks until ctx is canceled or an unrecoverable error occurs. It
+	// invokes handler for every event_callback payload received.
+	Run(ctx context.Context, handler func(SlackEventPayload) error) error
+}
+
+// httpEventSource is the original transport: Slack posts events to a public
+// /slack/events webhook, whose signature we verify before dispatching.
+// Events are deduped and handed to a bounded worker pool so a slow handler
+// never holds up the HTTP response Slack expects within 3 seconds.
+type httpEventSource struct {
+	addr          string
+	signingSecret string
+	dedupe        *dedupeCache
+	pool          *workerPool
+	metrics       *eventMetrics
+}
+
+func (s *httpEventSource) Run(ctx context.Context, handler func(SlackEventPayload) error) error {
+	http.HandleFunc("/slack/events", verifySlackSignature(s.signingSecret, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rawEventData, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusInternalServerError)
+			return
+		}
+
+		var payload SlackEventPayload
+		if err := json.Unmarshal(rawEventData, &payload); err != nil {
+			http.Error(w, "Failed to parse request body", http.StatusBadRequest)
+			return
+		}
+
+		// Handle URL verification challenge
+		if payload.Type == "url_verification" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{
+				"challenge": payload.Challenge,
+			})
+			return
+		}
+
+		requestID := newRequestID()
+
+		if retryReason := r.Header.Get("X-Slack-Retry-Reason"); retryReason != "" {
+			log.Printf("Received Slack retry #%s (reason=%s) request_id=%s event_id=%s",
+				r.Header.Get("X-Slack-Retry-Num"), retryReason, requestID, payload.EventID)
+		}
+
+		// Respond immediately; the actual work happens on the worker pool
+		// below so it never blocks Slack's 3-second ACK budget.
+		w.WriteHeader(http.StatusOK)
+
+		if payload.Type == "event_callback" {
+			dispatchEvent(requestID, payload, s.dedupe, s.pool, s.metrics, handler)
+		}
+	}))
+
+	log.Printf("HTTP event source listening on %s", s.addr)
+	return http.ListenAndServe(s.addr, nil)
+}
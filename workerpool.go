@@ -0,0 +1,33 @@
+package main
+
+// workerPool runs submitted jobs on a fixed number of goroutines, bounded by
+// a buffered channel so a slow downstream (Acorn) applies backpressure
+// instead of spawning unbounded goroutines per event.
+type workerPool struct {
+	jobs chan func()
+}
+
+func newWorkerPool(concurrency, queueSize int) *workerPool {
+	p := &workerPool{jobs: make(chan func(), queueSize)}
+	for i := 0; i < concurrency; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *workerPool) worker() {
+	for job := range p.jobs {
+		job()
+	}
+}
+
+// Submit enqueues job and returns true, or returns false immediately if the
+// queue is full rather than blocking the caller.
+func (p *workerPool) Submit(job func()) bool {
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}